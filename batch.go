@@ -0,0 +1,204 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchConfig configures the buffering behaviour of a Client returned by
+// NewBatchingHTTPClient.
+type BatchConfig struct {
+	// MaxPoints is the maximum number of buffered points before a flush is
+	// triggered. Zero means point count never triggers a flush.
+	MaxPoints int
+
+	// MaxBytes is the maximum number of buffered line-protocol bytes
+	// before a flush is triggered. Zero means size never triggers a flush.
+	MaxBytes int
+
+	// FlushInterval is the maximum amount of time buffered points are held
+	// before being flushed, regardless of MaxPoints/MaxBytes. Zero
+	// disables time-based flushing.
+	FlushInterval time.Duration
+
+	// OnError, if set, is called with the error from any flush performed
+	// in the background, i.e. every flush triggered by FlushInterval or by
+	// Close, and every flush triggered by MaxPoints/MaxBytes since those
+	// are also sent asynchronously. Write itself never returns a flush
+	// error.
+	OnError func(error)
+}
+
+// NewBatchingHTTPClient returns a Client backed by an HTTP client built
+// from conf, whose Write calls are buffered and coalesced according to
+// cfg before being sent. Write appends lineData to an in-memory buffer
+// keyed by the WriteConfig it was called with and returns immediately;
+// the buffer is flushed to the underlying client once MaxPoints or
+// MaxBytes is reached, or FlushInterval elapses, whichever comes first.
+// This trades a small amount of durability (buffered points are lost if
+// the process dies before a flush) for far fewer round trips under
+// high-ingest workloads.
+func NewBatchingHTTPClient(conf HTTPConfig, cfg BatchConfig) (Client, error) {
+	c, err := NewHTTPClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &batchingClient{
+		Client: c,
+		cfg:    cfg,
+	}, nil
+}
+
+// batchKey identifies the batch a Write call belongs to: points written
+// with differing WriteConfig fields cannot share a line-protocol buffer.
+type batchKey struct {
+	database         string
+	retentionPolicy  string
+	precision        string
+	writeConsistency string
+}
+
+func keyForWrite(cfg *WriteConfig) batchKey {
+	return batchKey{
+		database:         cfg.Database,
+		retentionPolicy:  cfg.RetentionPolicy,
+		precision:        cfg.Precision,
+		writeConsistency: cfg.WriteConsistency,
+	}
+}
+
+// batch holds the buffered line-protocol bytes for a single batchKey.
+type batch struct {
+	mu     sync.Mutex
+	cfg    WriteConfig
+	buf    bytes.Buffer
+	points int
+	timer  *time.Timer
+}
+
+// batchingClient wraps a Client, buffering Write calls per batchKey and
+// flushing them asynchronously through the embedded Client.
+type batchingClient struct {
+	Client
+	cfg BatchConfig
+
+	mu      sync.Mutex
+	batches map[batchKey]*batch
+}
+
+func (c *batchingClient) Write(lineData []byte, cfg *WriteConfig) error {
+	key := keyForWrite(cfg)
+
+	c.mu.Lock()
+	if c.batches == nil {
+		c.batches = make(map[batchKey]*batch)
+	}
+	b, ok := c.batches[key]
+	if !ok {
+		b = &batch{cfg: *cfg}
+		c.batches[key] = b
+	}
+	c.mu.Unlock()
+
+	b.mu.Lock()
+	b.buf.Write(lineData)
+	points := bytes.Count(lineData, []byte{'\n'})
+	if n := len(lineData); n > 0 && lineData[n-1] != '\n' {
+		b.buf.WriteByte('\n')
+		points++
+	}
+	b.points += points
+
+	full := (c.cfg.MaxPoints > 0 && b.points >= c.cfg.MaxPoints) ||
+		(c.cfg.MaxBytes > 0 && b.buf.Len() >= c.cfg.MaxBytes)
+	if !full {
+		if c.cfg.FlushInterval > 0 && b.timer == nil {
+			b.timer = time.AfterFunc(c.cfg.FlushInterval, func() { c.flush(b) })
+		}
+		b.mu.Unlock()
+		return nil
+	}
+	data := c.drain(b)
+	b.mu.Unlock()
+	c.writeAsync(data, &b.cfg)
+	return nil
+}
+
+// WriteContext buffers exactly like Write. The context is not threaded
+// through: buffering never performs I/O itself, only the asynchronous
+// flush it eventually triggers does, and that flush outlives any single
+// WriteContext call's ctx. Without this override, embedding would promote
+// the underlying Client's WriteContext directly, silently skipping
+// batching for every context-aware caller.
+func (c *batchingClient) WriteContext(ctx context.Context, lineData []byte, cfg *WriteConfig) error {
+	return c.Write(lineData, cfg)
+}
+
+// drain must be called with b.mu held. It stops any pending flush timer
+// and returns a copy of the buffered bytes, resetting the batch.
+func (c *batchingClient) drain(b *batch) []byte {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	data := make([]byte, b.buf.Len())
+	copy(data, b.buf.Bytes())
+	b.buf.Reset()
+	b.points = 0
+	return data
+}
+
+// flush is invoked by a batch's FlushInterval timer.
+func (c *batchingClient) flush(b *batch) {
+	b.mu.Lock()
+	b.timer = nil
+	if b.buf.Len() == 0 {
+		b.mu.Unlock()
+		return
+	}
+	data := c.drain(b)
+	b.mu.Unlock()
+	c.writeAsync(data, &b.cfg)
+}
+
+func (c *batchingClient) writeAsync(data []byte, cfg *WriteConfig) {
+	go func() {
+		if err := c.Client.Write(data, cfg); err != nil && c.cfg.OnError != nil {
+			c.cfg.OnError(err)
+		}
+	}()
+}
+
+// Close flushes any buffered points before releasing the underlying
+// client's resources.
+func (c *batchingClient) Close() error {
+	c.mu.Lock()
+	batches := c.batches
+	c.batches = nil
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		b.mu.Lock()
+		if b.buf.Len() == 0 {
+			b.mu.Unlock()
+			continue
+		}
+		data := c.drain(b)
+		cfg := b.cfg
+		b.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Client.Write(data, &cfg); err != nil && c.cfg.OnError != nil {
+				c.cfg.OnError(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return c.Client.Close()
+}
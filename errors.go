@@ -0,0 +1,79 @@
+package influxdb
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when a request to the /write endpoint fails with a
+// non-2xx status. More specific error kinds below embed it for callers
+// that need to distinguish why a write failed.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("received status code %d from server: %s", e.StatusCode, e.Body)
+}
+
+// DatabaseNotFoundError means the write targeted a database that does not
+// exist. Retrying the same batch will never succeed until the database is
+// created.
+type DatabaseNotFoundError struct{ *APIError }
+
+// PartialWriteError means some points in the batch were accepted and
+// others were rejected, e.g. because they fell outside every matching
+// retention policy. Retrying the whole batch would duplicate the points
+// that were already written.
+type PartialWriteError struct{ *APIError }
+
+// RetentionPolicyError means the write referenced a retention policy that
+// does not exist, or that its points fell outside the retention policy
+// without the server reporting a partial write.
+type RetentionPolicyError struct{ *APIError }
+
+// ParseError means the line protocol in the write body could not be
+// parsed.
+type ParseError struct{ *APIError }
+
+// parseWriteError classifies a failed /write response into one of the
+// typed errors above based on its body, falling back to a plain *APIError
+// when nothing more specific matches.
+func parseWriteError(statusCode int, body string) error {
+	base := &APIError{StatusCode: statusCode, Body: body}
+	switch {
+	case strings.Contains(body, "database not found"):
+		return &DatabaseNotFoundError{base}
+	case strings.Contains(body, "partial write"):
+		return &PartialWriteError{base}
+	case strings.Contains(body, "retention policy") && strings.Contains(body, "not found"),
+		strings.Contains(body, "points beyond retention policy"):
+		return &RetentionPolicyError{base}
+	case strings.Contains(body, "unable to parse"):
+		return &ParseError{base}
+	default:
+		return base
+	}
+}
+
+// retryableWriteError reports whether err represents a transient write
+// failure worth retrying (a 5xx response, or the request never reaching
+// the server at all) as opposed to a terminal one such as a parse error or
+// a missing database/retention policy.
+func retryableWriteError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch e := err.(type) {
+	case *DatabaseNotFoundError, *PartialWriteError, *RetentionPolicyError, *ParseError:
+		return false
+	case *APIError:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		// Connection refused, timeouts, DNS failures etc. surface as
+		// plain errors from net/http, never reaching parseWriteError.
+		return true
+	}
+}
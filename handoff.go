@@ -0,0 +1,166 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// HintedHandoffConfig configures the on-disk retry spool used by
+// NewHintedHandoffClient.
+type HintedHandoffConfig struct {
+	// Dir is the directory the spool's segment and checkpoint files live
+	// in. It is created if it does not already exist, and is safe to
+	// reuse across process restarts.
+	Dir string
+
+	// MaxSize is the maximum total size in bytes the spool may grow to.
+	// Writes that would need to be queued once the spool is at MaxSize
+	// fail with the original write error instead of being persisted.
+	// Zero means unbounded.
+	MaxSize int64
+
+	// InitialBackoff is the base of the exponential backoff. A newly
+	// queued batch is replayed at once; each failed replay doubles the
+	// delay before the next attempt, starting from InitialBackoff, so the
+	// first wait after a failure is twice InitialBackoff. It is also the
+	// interval at which an empty spool is polled. Defaults to one second.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the exponential backoff applied between retries of
+	// a batch that keeps failing. Defaults to one minute.
+	MaxBackoff time.Duration
+}
+
+// NewHintedHandoffClient wraps client with a disk-backed retry spool. When
+// a Write fails with a retryable error (a 5xx response from the server, or
+// the request never reaching it at all, e.g. connection refused or a
+// timeout) the line-protocol batch is appended to the spool under
+// cfg.Dir instead of being lost, and Write returns success. A background
+// goroutine replays spooled batches against client with exponential
+// backoff until each succeeds. Terminal errors (ParseError,
+// PartialWriteError, RetentionPolicyError, DatabaseNotFoundError) are
+// never spooled; they are returned to the caller immediately, and if
+// encountered during replay the batch is dropped since resending it could
+// never succeed.
+func NewHintedHandoffClient(client Client, cfg HintedHandoffConfig) (Client, error) {
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff = defaultInitialBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	s, err := openSpool(cfg.Dir, cfg.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	c := &handoffClient{
+		Client: client,
+		cfg:    cfg,
+		spool:  s,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go c.replayLoop()
+	c.wake <- struct{}{}
+	return c, nil
+}
+
+// handoffClient wraps a Client, spooling retryable write failures to disk
+// and replaying them in the background.
+type handoffClient struct {
+	Client
+	cfg   HintedHandoffConfig
+	spool *spool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func (c *handoffClient) Write(lineData []byte, cfg *WriteConfig) error {
+	return c.handle(c.Client.Write(lineData, cfg), lineData, cfg)
+}
+
+// WriteContext behaves like Write, spooling the batch on a retryable
+// failure. Without this override, embedding would promote the underlying
+// Client's WriteContext directly, so a context-aware caller's failed
+// writes would never reach the spool - exactly the data loss the spool
+// exists to prevent.
+func (c *handoffClient) WriteContext(ctx context.Context, lineData []byte, cfg *WriteConfig) error {
+	return c.handle(c.Client.WriteContext(ctx, lineData, cfg), lineData, cfg)
+}
+
+// handle inspects the error from an underlying write attempt, spooling
+// lineData/cfg when it's retryable and returning nil, or returning err
+// unchanged otherwise.
+func (c *handoffClient) handle(err error, lineData []byte, cfg *WriteConfig) error {
+	if err == nil {
+		return nil
+	}
+	if !retryableWriteError(err) {
+		return err
+	}
+	if spoolErr := c.spool.append(lineData, cfg); spoolErr != nil {
+		// The batch could not be preserved: surface the original error
+		// rather than silently dropping the data.
+		return err
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops the background replay loop and releases the underlying
+// client and spool. Any batches still queued remain on disk and will be
+// replayed by the next HintedHandoffClient opened on the same Dir.
+func (c *handoffClient) Close() error {
+	close(c.done)
+	if err := c.spool.close(); err != nil {
+		return err
+	}
+	return c.Client.Close()
+}
+
+func (c *handoffClient) replayLoop() {
+	backoff := c.cfg.InitialBackoff
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.wake:
+		case <-time.After(backoff):
+		}
+
+		entry, err := c.spool.peek()
+		if err != nil || entry == nil {
+			backoff = c.cfg.InitialBackoff
+			continue
+		}
+
+		if err := c.Client.Write(entry.Data, &entry.Cfg); err != nil {
+			if !retryableWriteError(err) {
+				// Terminal: there's nothing more we can do for this batch.
+				c.spool.advance()
+				continue
+			}
+			backoff *= 2
+			if backoff > c.cfg.MaxBackoff {
+				backoff = c.cfg.MaxBackoff
+			}
+			continue
+		}
+
+		c.spool.advance()
+		backoff = c.cfg.InitialBackoff
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+}
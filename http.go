@@ -2,16 +2,35 @@ package influxdb
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
+// Compression selects the HTTP body compression used for write and query
+// requests.
+type Compression int
+
+const (
+	// CompressionNone sends and expects uncompressed bodies. This is the
+	// default.
+	CompressionNone Compression = iota
+
+	// CompressionGzip gzips write request bodies and sets
+	// Accept-Encoding: gzip on write and query requests, transparently
+	// decoding any gzipped response.
+	CompressionGzip
+)
+
 // HTTPConfig is the config data needed to create an HTTP Client
 type HTTPConfig struct {
 	// Addr should be of the form "http://host:port"
@@ -37,6 +56,10 @@ type HTTPConfig struct {
 	// TLSConfig allows the user to set their own TLS config for the HTTP
 	// Client. If set, this option overrides InsecureSkipVerify.
 	TLSConfig *tls.Config
+
+	// Compression selects gzip compression for write bodies and query
+	// responses. Defaults to CompressionNone.
+	Compression Compression
 }
 
 // Client is a client interface for writing & querying the database
@@ -44,17 +67,45 @@ type Client interface {
 	// Ping checks that status of cluster
 	Ping(timeout time.Duration) (time.Duration, string, error)
 
+	// PingContext is like Ping but aborts the request as soon as ctx is
+	// done; timeout still only bounds the server's wait for a leader.
+	PingContext(ctx context.Context, timeout time.Duration) (time.Duration, string, error)
+
 	// Write takes a BatchPoints object and writes all Points to InfluxDB.
 	Write(lineData []byte, cfg *WriteConfig) error
 
+	// WriteContext is like Write but aborts the request as soon as ctx is
+	// done.
+	WriteContext(ctx context.Context, lineData []byte, cfg *WriteConfig) error
+
 	// Query makes an InfluxDB Query on the database. This will fail if using
 	// the UDP client.
 	Query(q Query) (*Response, error)
 
+	// QueryContext is like Query but aborts the request as soon as ctx is
+	// done.
+	QueryContext(ctx context.Context, q Query) (*Response, error)
+
+	// QueryChunked makes an InfluxDB Query against the /query endpoint with
+	// chunking enabled, and streams the results back one Result at a time
+	// over the returned channel instead of buffering the whole Response in
+	// memory. The channel is closed once the server has sent the last chunk
+	// or an error occurs; a chunk carrying an error is sent with Result.Err
+	// set. This will fail if using the UDP client.
+	QueryChunked(q Query) (<-chan *Result, error)
+
+	// QueryChunkedContext is like QueryChunked but aborts the request as
+	// soon as ctx is done.
+	QueryChunkedContext(ctx context.Context, q Query) (<-chan *Result, error)
+
 	// Close releases any resources a Client may be using.
 	Close() error
 }
 
+// DefaultChunkSize is the number of points per chunk used by QueryChunked
+// when a Query does not specify its own ChunkSize.
+const DefaultChunkSize = 10000
+
 // NewHTTPClient returns a new Client from the provided config.
 // Client is safe for concurrent use by multiple goroutines.
 func NewHTTPClient(conf HTTPConfig) (Client, error) {
@@ -80,10 +131,11 @@ func NewHTTPClient(conf HTTPConfig) (Client, error) {
 		tr.TLSClientConfig = conf.TLSConfig
 	}
 	return &httpClient{
-		url:       *u,
-		username:  conf.Username,
-		password:  conf.Password,
-		useragent: conf.UserAgent,
+		url:         *u,
+		username:    conf.Username,
+		password:    conf.Password,
+		useragent:   conf.UserAgent,
+		compression: conf.Compression,
 		httpClient: &http.Client{
 			Timeout:   conf.Timeout,
 			Transport: tr,
@@ -95,6 +147,11 @@ func NewHTTPClient(conf HTTPConfig) (Client, error) {
 // Ping will check to see if the server is up with an optional timeout on waiting for leader.
 // Ping returns how long the request took, the version of the server it connected to, and an error if one occurred.
 func (c *httpClient) Ping(timeout time.Duration) (time.Duration, string, error) {
+	return c.PingContext(context.Background(), timeout)
+}
+
+// PingContext is like Ping but aborts the request as soon as ctx is done.
+func (c *httpClient) PingContext(ctx context.Context, timeout time.Duration) (time.Duration, string, error) {
 	now := time.Now()
 	u := c.url
 	u.Path = "ping"
@@ -103,6 +160,7 @@ func (c *httpClient) Ping(timeout time.Duration) (time.Duration, string, error)
 	if err != nil {
 		return 0, "", err
 	}
+	req = req.WithContext(ctx)
 
 	req.Header.Set("User-Agent", c.useragent)
 
@@ -147,12 +205,41 @@ func (c *httpClient) Close() error {
 type httpClient struct {
 	// N.B - if url.UserInfo is accessed in future modifications to the
 	// methods on client, you will need to syncronise access to url.
-	url        url.URL
-	username   string
-	password   string
-	useragent  string
-	httpClient *http.Client
-	transport  *http.Transport
+	url         url.URL
+	username    string
+	password    string
+	useragent   string
+	compression Compression
+	httpClient  *http.Client
+	transport   *http.Transport
+}
+
+// gzipBody compresses data for use as a request body when gzip compression
+// is enabled, returning the original data otherwise.
+func (c *httpClient) gzipBody(data []byte) ([]byte, error) {
+	if c.compression != CompressionGzip {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// responseReader transparently gunzips resp.Body when the server sent a
+// gzipped response; resp.Body is always returned as part of the reader
+// chain, so closing the returned reader's underlying resp.Body remains the
+// caller's responsibility.
+func responseReader(resp *http.Response) (io.Reader, error) {
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		return gzip.NewReader(resp.Body)
+	}
+	return resp.Body, nil
 }
 
 func (bp *BatchPoints) Bytes(precision string) []byte {
@@ -165,14 +252,30 @@ func (bp *BatchPoints) Bytes(precision string) []byte {
 }
 
 func (c *httpClient) Write(lineData []byte, cfg *WriteConfig) error {
+	return c.WriteContext(context.Background(), lineData, cfg)
+}
+
+// WriteContext is like Write but aborts the request as soon as ctx is
+// done.
+func (c *httpClient) WriteContext(ctx context.Context, lineData []byte, cfg *WriteConfig) error {
 	u := c.url
 	u.Path = "write"
-	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(lineData))
+
+	body, err := c.gzipBody(lineData)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "")
 	req.Header.Set("User-Agent", c.useragent)
+	if c.compression == CompressionGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
 	if c.username != "" {
 		req.SetBasicAuth(c.username, c.password)
 	}
@@ -196,14 +299,17 @@ func (c *httpClient) Write(lineData []byte, cfg *WriteConfig) error {
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	reader, err := responseReader(resp)
+	if err != nil {
+		return err
+	}
+	respBody, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return err
 	}
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		var err = fmt.Errorf(string(body))
-		return err
+		return parseWriteError(resp.StatusCode, string(respBody))
 	}
 
 	return nil
@@ -211,6 +317,12 @@ func (c *httpClient) Write(lineData []byte, cfg *WriteConfig) error {
 
 // Query sends a command to the server and returns the Response
 func (c *httpClient) Query(q Query) (*Response, error) {
+	return c.QueryContext(context.Background(), q)
+}
+
+// QueryContext is like Query but aborts the request as soon as ctx is
+// done.
+func (c *httpClient) QueryContext(ctx context.Context, q Query) (*Response, error) {
 	u := c.url
 	u.Path = "query"
 
@@ -218,8 +330,12 @@ func (c *httpClient) Query(q Query) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "")
 	req.Header.Set("User-Agent", c.useragent)
+	if c.compression == CompressionGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
 	if c.username != "" {
 		req.SetBasicAuth(c.username, c.password)
 	}
@@ -238,8 +354,12 @@ func (c *httpClient) Query(q Query) (*Response, error) {
 	}
 	defer resp.Body.Close()
 
+	reader, err := responseReader(resp)
+	if err != nil {
+		return nil, err
+	}
 	var response Response
-	dec := json.NewDecoder(resp.Body)
+	dec := json.NewDecoder(reader)
 	dec.UseNumber()
 	decErr := dec.Decode(&response)
 
@@ -259,3 +379,87 @@ func (c *httpClient) Query(q Query) (*Response, error) {
 	}
 	return &response, nil
 }
+
+// QueryChunked sends a command to the server with chunking enabled and
+// streams the decoded Results back over the returned channel as they arrive
+// on the wire, rather than buffering the full Response in memory.
+func (c *httpClient) QueryChunked(q Query) (<-chan *Result, error) {
+	return c.QueryChunkedContext(context.Background(), q)
+}
+
+// QueryChunkedContext is like QueryChunked but aborts the request as soon
+// as ctx is done.
+func (c *httpClient) QueryChunkedContext(ctx context.Context, q Query) (<-chan *Result, error) {
+	u := c.url
+	u.Path = "query"
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "")
+	req.Header.Set("User-Agent", c.useragent)
+	if c.compression == CompressionGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	chunkSize := q.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	params := req.URL.Query()
+	params.Set("q", q.Command)
+	params.Set("db", q.Database)
+	if q.Precision != "" {
+		params.Set("epoch", q.Precision)
+	}
+	params.Set("chunked", "true")
+	params.Set("chunk_size", strconv.Itoa(chunkSize))
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("received status code %d from server: %s", resp.StatusCode, body)
+	}
+
+	results := make(chan *Result)
+	go func() {
+		defer resp.Body.Close()
+		defer close(results)
+
+		reader, err := responseReader(resp)
+		if err != nil {
+			results <- &Result{Err: err.Error()}
+			return
+		}
+		dec := json.NewDecoder(reader)
+		dec.UseNumber()
+		for {
+			var response Response
+			if err := dec.Decode(&response); err != nil {
+				if err != io.EOF {
+					results <- &Result{Err: err.Error()}
+				}
+				return
+			}
+			if response.Err != "" {
+				results <- &Result{Err: response.Err}
+				return
+			}
+			for i := range response.Results {
+				results <- &response.Results[i]
+			}
+		}
+	}()
+	return results, nil
+}
@@ -1,12 +1,14 @@
 package influxdb
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -26,7 +28,27 @@ func Open(name string) (driver.Conn, error) {
 	}
 	switch uri.Scheme {
 	case "udp":
-		panic("udp scheme is not supported by the driver")
+		query := uri.Query()
+		payloadSize, _ := strconv.Atoi(query.Get("payload_size"))
+		writeBufferSize, _ := strconv.Atoi(query.Get("write_buffer_size"))
+		precision := query.Get("precision")
+		if precision == "" {
+			precision = "ns"
+		}
+		c, err := NewUDPClient(UDPConfig{
+			Addr:            uri.Host,
+			PayloadSize:     payloadSize,
+			WriteBufferSize: writeBufferSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		database := strings.TrimPrefix(uri.Path, `/`)
+		return &conn{
+			c:         c,
+			database:  database,
+			precision: precision,
+		}, nil
 	case "http":
 		var (
 			user     string
@@ -43,13 +65,18 @@ func Open(name string) (driver.Conn, error) {
 		if precision == "" {
 			precision = "ns"
 		}
-		c, err := NewHTTPClient(HTTPConfig{
+		chunkSize, _ := strconv.Atoi(query.Get("chunk_size"))
+		httpConf := HTTPConfig{
 			Addr:      addr,
 			Username:  user,
 			Password:  password,
 			UserAgent: query.Get("ua"),
 			Timeout:   timeout,
-		})
+		}
+		if query.Get("gzip") == "true" {
+			httpConf.Compression = CompressionGzip
+		}
+		c, err := newDSNClient(httpConf, query)
 		if err != nil {
 			return nil, err
 		}
@@ -58,19 +85,46 @@ func Open(name string) (driver.Conn, error) {
 			c:         c,
 			database:  database,
 			precision: precision,
+			chunkSize: chunkSize,
 		}, nil
 	}
-	// TODO: support https, udp
+	// TODO: support https
 	return nil, fmt.Errorf("unsupported scheme %s", uri.Scheme)
 }
 
+// newDSNClient builds the Client for a parsed DSN's query string, wrapping
+// it in a batching Client when any batch_* parameter is present so that
+// tx.Commit and insertStmt.Exec transparently benefit from batched writes.
+func newDSNClient(conf HTTPConfig, query url.Values) (Client, error) {
+	maxPoints, _ := strconv.Atoi(query.Get("batch_max_points"))
+	maxBytes, _ := strconv.Atoi(query.Get("batch_max_bytes"))
+	flushInterval, _ := time.ParseDuration(query.Get("batch_flush_interval"))
+	if maxPoints == 0 && maxBytes == 0 && flushInterval == 0 {
+		return NewHTTPClient(conf)
+	}
+	return NewBatchingHTTPClient(conf, BatchConfig{
+		MaxPoints:     maxPoints,
+		MaxBytes:      maxBytes,
+		FlushInterval: flushInterval,
+	})
+}
+
 type conn struct {
 	c         Client
 	database  string
 	precision string
+	chunkSize int
 	tx        *tx
 }
 
+// query builds the Query for a statement issued on this connection,
+// carrying over the chunk size configured on the DSN, if any.
+func (c *conn) query(command string) Query {
+	q := NewQuery(command, c.database, c.precision)
+	q.ChunkSize = c.chunkSize
+	return q
+}
+
 func (c *conn) Prepare(query string) (driver.Stmt, error) {
 	return newStmt(c, query)
 }
@@ -88,6 +142,62 @@ func (c *conn) Begin() (driver.Tx, error) {
 	return c.tx, err
 }
 
+// BeginTx implements driver.ConnBeginTx. InfluxDB has no notion of
+// isolation levels or read-only transactions, so opts is ignored; ctx is
+// unused too since Begin itself performs no I/O, only Commit does.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Begin()
+}
+
+// Ping implements driver.Pinger so sql.DB.PingContext aborts as soon as
+// ctx is done instead of waiting out the underlying client's timeout.
+func (c *conn) Ping(ctx context.Context) error {
+	_, _, err := c.c.PingContext(ctx, 0)
+	return err
+}
+
+// ExecContext implements driver.ExecerContext, mirroring stmt.Exec and
+// insertStmt.Exec but routing the request through the context-aware
+// Client methods so cancellation and deadlines abort the in-flight HTTP
+// call. Because database/sql prefers ExecerContext over the Stmt path
+// even for plain (non-context) Exec calls, this is now the primary INSERT
+// path for any DSN that requested batching, so it depends on WriteContext
+// being implemented by the batching Client rather than falling back to the
+// embedded httpClient's WriteContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if m := rxInsert.FindStringSubmatch(query); len(m) == 3 {
+		db, insertQuery := m[1], m[2]
+		err := c.c.WriteContext(ctx, []byte(insertQuery), &WriteConfig{Database: db})
+		return &result{0, 0}, err
+	}
+	resp, err := c.c.QueryContext(ctx, c.query(query))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error() != nil {
+		return nil, resp.Error()
+	}
+	return &result{0, len(resp.Results)}, nil
+}
+
+// QueryContext implements driver.QueryerContext, mirroring stmt.Query:
+// results stream through the chunked path instead of buffering the whole
+// Response in memory, same as the non-context-aware query path.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if m := rxInsert.FindStringSubmatch(query); len(m) == 3 {
+		db, insertQuery := m[1], m[2]
+		if err := c.c.WriteContext(ctx, []byte(insertQuery), &WriteConfig{Database: db}); err != nil {
+			return nil, err
+		}
+		return emptyRows(), nil
+	}
+	results, err := c.c.QueryChunkedContext(ctx, c.query(query))
+	if err != nil {
+		return nil, err
+	}
+	return newChunkedRows(results)
+}
+
 type insertStmt struct {
 	conn  *conn
 	db    string
@@ -106,7 +216,10 @@ func (s *insertStmt) Exec(args []driver.Value) (driver.Result, error) {
 }
 
 func (s *insertStmt) Query(args []driver.Value) (driver.Rows, error) {
-	panic("not supported yet")
+	if err := s.conn.c.Write([]byte(s.query), &WriteConfig{Database: s.db}); err != nil {
+		return nil, err
+	}
+	return emptyRows(), nil
 }
 
 type stmt struct {
@@ -148,7 +261,11 @@ func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
 }
 
 func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
-	panic("not supported yet")
+	results, err := s.conn.c.QueryChunked(s.conn.query(s.query))
+	if err != nil {
+		return nil, err
+	}
+	return newChunkedRows(results)
 }
 
 type result struct {
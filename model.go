@@ -79,6 +79,11 @@ type Query struct {
 	Command   string
 	Database  string
 	Precision string
+
+	// ChunkSize is the number of points the server should return per chunk
+	// when the query is issued through Client.QueryChunked. A zero value
+	// means DefaultChunkSize is used.
+	ChunkSize int
 }
 
 // NewQuery returns a query object
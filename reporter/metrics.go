@@ -0,0 +1,206 @@
+package reporter
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a running total, e.g. a request count.
+type Counter struct {
+	count int64
+}
+
+// NewCounter returns a Counter starting at zero.
+func NewCounter() *Counter { return &Counter{} }
+
+// Inc adds delta to the counter. delta may be negative.
+func (c *Counter) Inc(delta int64) { atomic.AddInt64(&c.count, delta) }
+
+// Snapshot implements Metric.
+func (c *Counter) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"count": atomic.LoadInt64(&c.count)}
+}
+
+// Gauge holds a single instantaneous value, e.g. queue depth.
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// NewGauge returns a Gauge starting at zero.
+func NewGauge() *Gauge { return &Gauge{} }
+
+// Set replaces the gauge's current value.
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Snapshot implements Metric.
+func (g *Gauge) Snapshot() map[string]interface{} {
+	g.mu.Lock()
+	v := g.value
+	g.mu.Unlock()
+	return map[string]interface{}{"value": v}
+}
+
+// reservoirSize bounds the number of observations a Histogram or Timer
+// keeps in memory. Past that point, older samples are displaced via
+// Algorithm R reservoir sampling as new ones arrive, so both the memory
+// held and the cost of sorting on Snapshot stay constant no matter how
+// long the Reporter runs, while the kept sample remains a uniform random
+// subset of everything observed.
+const reservoirSize = 1028
+
+// reservoir implements Algorithm R reservoir sampling over float64
+// observations. It is not safe for concurrent use; callers are expected to
+// hold their own lock, as Histogram and Timer do.
+type reservoir struct {
+	samples []float64
+	count   int64
+}
+
+// update records a single observation.
+func (r *reservoir) update(v float64) {
+	r.count++
+	if int64(len(r.samples)) < reservoirSize {
+		r.samples = append(r.samples, v)
+		return
+	}
+	if i := rand.Int63n(r.count); i < reservoirSize {
+		r.samples[i] = v
+	}
+}
+
+// snapshot returns a copy of the kept sample along with the true total
+// number of observations, which once the reservoir has filled exceeds
+// len(sample).
+func (r *reservoir) snapshot() (sample []float64, count int64) {
+	return append([]float64(nil), r.samples...), r.count
+}
+
+// Histogram accumulates a bounded reservoir sample of observed values and
+// reports count/min/max/mean/p50/p95/p99 on Snapshot.
+type Histogram struct {
+	mu sync.Mutex
+	r  reservoir
+}
+
+// NewHistogram returns an empty Histogram.
+func NewHistogram() *Histogram { return &Histogram{} }
+
+// Update records a single observation.
+func (h *Histogram) Update(v float64) {
+	h.mu.Lock()
+	h.r.update(v)
+	h.mu.Unlock()
+}
+
+// Snapshot implements Metric.
+func (h *Histogram) Snapshot() map[string]interface{} {
+	h.mu.Lock()
+	samples, count := h.r.snapshot()
+	h.mu.Unlock()
+	return sampleFields(samples, count)
+}
+
+// Timer accumulates a bounded reservoir sample of durations and reports
+// count/min/max/mean/p50/p95/p99 (in nanoseconds) on Snapshot.
+type Timer struct {
+	mu sync.Mutex
+	r  reservoir
+}
+
+// NewTimer returns an empty Timer.
+func NewTimer() *Timer { return &Timer{} }
+
+// Update records a single observation.
+func (t *Timer) Update(d time.Duration) {
+	t.mu.Lock()
+	t.r.update(float64(d))
+	t.mu.Unlock()
+}
+
+// Snapshot implements Metric.
+func (t *Timer) Snapshot() map[string]interface{} {
+	t.mu.Lock()
+	samples, count := t.r.snapshot()
+	t.mu.Unlock()
+	return sampleFields(samples, count)
+}
+
+// ResettingTimer is a Timer whose Snapshot atomically swaps in a fresh
+// sample, so each report covers only what was observed since the previous
+// tick. This matters for low-traffic timers: a Timer that never resets
+// would dilute recent percentiles with samples going back to process
+// start.
+type ResettingTimer struct {
+	mu      sync.Mutex
+	samples []float64
+}
+
+// NewResettingTimer returns an empty ResettingTimer.
+func NewResettingTimer() *ResettingTimer { return &ResettingTimer{} }
+
+// Update records a single observation.
+func (t *ResettingTimer) Update(d time.Duration) {
+	t.mu.Lock()
+	t.samples = append(t.samples, float64(d))
+	t.mu.Unlock()
+}
+
+// Snapshot implements Metric, clearing the recorded sample so the next
+// tick starts from zero.
+func (t *ResettingTimer) Snapshot() map[string]interface{} {
+	t.mu.Lock()
+	samples := t.samples
+	t.samples = nil
+	t.mu.Unlock()
+	return sampleFields(samples, int64(len(samples)))
+}
+
+// sampleFields computes the aggregate statistics shared by Histogram,
+// Timer and ResettingTimer. count is the true number of observations the
+// sample was drawn from, which for a reservoir-bounded sample may exceed
+// len(samples).
+func sampleFields(samples []float64, count int64) map[string]interface{} {
+	if count == 0 {
+		return map[string]interface{}{"count": int64(0)}
+	}
+	sort.Float64s(samples)
+
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+
+	return map[string]interface{}{
+		"count": count,
+		"min":   samples[0],
+		"max":   samples[len(samples)-1],
+		"mean":  sum / float64(len(samples)),
+		"p50":   percentile(samples, 0.50),
+		"p95":   percentile(samples, 0.95),
+		"p99":   percentile(samples, 0.99),
+	}
+}
+
+// percentile computes the p-th percentile (0<=p<=1) of an already-sorted
+// sample using linear interpolation between the two nearest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	idx := p * float64(len(sorted)-1)
+	lo := int(idx)
+	hi := lo + 1
+	if hi >= len(sorted) {
+		return sorted[lo]
+	}
+	frac := idx - float64(lo)
+	return sorted[lo] + frac*(sorted[hi]-sorted[lo])
+}
@@ -0,0 +1,53 @@
+package reporter
+
+import "testing"
+
+func TestSampleFieldsEmpty(t *testing.T) {
+	fields := sampleFields(nil, 0)
+	if fields["count"] != int64(0) {
+		t.Fatalf("expect count 0, got %v", fields["count"])
+	}
+}
+
+func TestResettingTimerResets(t *testing.T) {
+	rt := NewResettingTimer()
+	rt.Update(10)
+	rt.Update(20)
+
+	first := rt.Snapshot()
+	if first["count"] != int64(2) {
+		t.Fatalf("expect count 2, got %v", first["count"])
+	}
+
+	second := rt.Snapshot()
+	if second["count"] != int64(0) {
+		t.Fatalf("expect sample to be cleared after Snapshot, got count %v", second["count"])
+	}
+}
+
+func TestHistogramReservoirBounded(t *testing.T) {
+	h := NewHistogram()
+	for i := 0; i < reservoirSize*3; i++ {
+		h.Update(float64(i))
+	}
+	if got := len(h.r.samples); got > reservoirSize {
+		t.Fatalf("expect sample bounded to %d, got %d", reservoirSize, got)
+	}
+	fields := h.Snapshot()
+	if fields["count"] != int64(reservoirSize*3) {
+		t.Fatalf("expect count %d, got %v", reservoirSize*3, fields["count"])
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	if got := percentile(sorted, 0); got != 1 {
+		t.Fatalf("expect p0 to be 1, got %v", got)
+	}
+	if got := percentile(sorted, 1); got != 5 {
+		t.Fatalf("expect p100 to be 5, got %v", got)
+	}
+	if got := percentile(sorted, 0.5); got != 3 {
+		t.Fatalf("expect p50 to be 3, got %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package reporter periodically converts an arbitrary metrics registry
+// into line-protocol writes through an influxdb.Client.
+package reporter
+
+import (
+	"sync"
+	"time"
+
+	influxdb "h12.me/influxdb-driver"
+)
+
+// Metric is the interface a caller's metric must satisfy to be registered
+// with a Reporter. It lets go-metrics, expvar or custom instrumentation
+// plug in without this package depending on any of them directly.
+type Metric interface {
+	// Snapshot returns the metric's current value(s), keyed by the
+	// line-protocol field name each should be written under.
+	Snapshot() map[string]interface{}
+}
+
+// Config configures a Reporter.
+type Config struct {
+	// Database is the InfluxDB database written to on every tick.
+	Database string
+
+	// Interval is how often registered metrics are snapshotted and
+	// written.
+	Interval time.Duration
+
+	// Tags are attached to every point written by the Reporter.
+	Tags map[string]string
+
+	// Precision is the write precision used for each point. Defaults to
+	// "s".
+	Precision string
+
+	// OnError, if set, is called whenever a write fails.
+	OnError func(error)
+}
+
+// Reporter periodically snapshots a set of registered metrics and writes
+// them to InfluxDB as a single BatchPoints, one Point per metric.
+type Reporter struct {
+	client influxdb.Client
+	cfg    Config
+
+	mu      sync.Mutex
+	metrics map[string]Metric
+
+	done chan struct{}
+}
+
+// New returns a Reporter that writes through client. Call Run to start the
+// reporting loop.
+func New(client influxdb.Client, cfg Config) *Reporter {
+	if cfg.Precision == "" {
+		cfg.Precision = "s"
+	}
+	return &Reporter{
+		client:  client,
+		cfg:     cfg,
+		metrics: make(map[string]Metric),
+		done:    make(chan struct{}),
+	}
+}
+
+// Register adds a metric to be reported under name on every tick.
+// Registering the same name again replaces the previously registered
+// metric.
+func (r *Reporter) Register(name string, m Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = m
+}
+
+// Unregister removes a previously registered metric.
+func (r *Reporter) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.metrics, name)
+}
+
+// Run starts the reporting loop, blocking until Stop is called. Run is
+// typically invoked in its own goroutine.
+func (r *Reporter) Run() {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if err := r.reportOnce(); err != nil && r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+// Stop ends the reporting loop started by Run.
+func (r *Reporter) Stop() {
+	close(r.done)
+}
+
+func (r *Reporter) reportOnce() error {
+	bp := &influxdb.BatchPoints{}
+	now := time.Now()
+
+	r.mu.Lock()
+	for name, m := range r.metrics {
+		fields := m.Snapshot()
+		if len(fields) == 0 {
+			continue
+		}
+		p, err := influxdb.NewPoint(name, r.cfg.Tags, fields, now)
+		if err != nil {
+			continue
+		}
+		bp.AddPoint(p)
+	}
+	r.mu.Unlock()
+
+	if len(bp.Points()) == 0 {
+		return nil
+	}
+	return r.client.Write(bp.Bytes(r.cfg.Precision), &influxdb.WriteConfig{
+		Database:  r.cfg.Database,
+		Precision: r.cfg.Precision,
+	})
+}
@@ -0,0 +1,156 @@
+package influxdb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/influxdata/influxdb/models"
+)
+
+// chunkedRows implements driver.Rows (and driver.RowsColumnTypeScanType) on
+// top of the channel of Results produced by Client.QueryChunked, so that
+// sql.DB.Query can stream arbitrarily large result sets one models.Row value
+// at a time instead of buffering the whole Response in memory.
+type chunkedRows struct {
+	results <-chan *Result
+
+	series []models.Row
+	rowIdx int
+	valIdx int
+
+	columns   []string
+	scanTypes []reflect.Type
+}
+
+func newChunkedRows(results <-chan *Result) (*chunkedRows, error) {
+	r := &chunkedRows{results: results}
+	if err := r.nextSeries(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return r, nil
+}
+
+// nextSeries advances to the next non-empty models.Row slice, pulling
+// further chunks off the results channel as needed. It returns io.EOF once
+// the channel is drained.
+func (r *chunkedRows) nextSeries() error {
+	for {
+		result, ok := <-r.results
+		if !ok {
+			return io.EOF
+		}
+		if result.Err != "" {
+			return errors.New(result.Err)
+		}
+		if len(result.Series) == 0 {
+			continue
+		}
+		r.series = result.Series
+		r.rowIdx = 0
+		r.valIdx = 0
+		if r.columns == nil {
+			r.setColumns(result.Series[0])
+		}
+		return nil
+	}
+}
+
+// setColumns derives column names and scan types from the first row of a
+// result. The "time" column is intentionally not special-cased to
+// time.Time here: the server only emits RFC3339 strings for it when the
+// query carries no epoch/precision, and emits an epoch number otherwise
+// (the common case, since conn.precision defaults to "ns") - either way
+// columnValue produces a string or an int64/float64, never a time.Time, so
+// the scan type must track whichever of those columnScanType infers from
+// the actual value.
+func (r *chunkedRows) setColumns(row models.Row) {
+	r.columns = row.Columns
+	r.scanTypes = make([]reflect.Type, len(row.Columns))
+	if len(row.Values) == 0 {
+		return
+	}
+	for i, v := range row.Values[0] {
+		r.scanTypes[i] = columnScanType(v)
+	}
+}
+
+// columnScanType maps a decoded JSON value (as produced by a
+// json.Decoder with UseNumber enabled) to the Go type sql callers should
+// expect back from Scan.
+func columnScanType(v interface{}) reflect.Type {
+	switch n := v.(type) {
+	case string:
+		return reflect.TypeOf("")
+	case bool:
+		return reflect.TypeOf(false)
+	case json.Number:
+		if _, err := n.Int64(); err == nil {
+			return reflect.TypeOf(int64(0))
+		}
+		return reflect.TypeOf(float64(0))
+	default:
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+}
+
+func (r *chunkedRows) Columns() []string {
+	return r.columns
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (r *chunkedRows) ColumnTypeScanType(index int) reflect.Type {
+	if index < len(r.scanTypes) && r.scanTypes[index] != nil {
+		return r.scanTypes[index]
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+func (r *chunkedRows) Close() error {
+	for range r.results {
+	}
+	return nil
+}
+
+func (r *chunkedRows) Next(dest []driver.Value) error {
+	for r.rowIdx < len(r.series) {
+		row := r.series[r.rowIdx]
+		if r.valIdx < len(row.Values) {
+			for i, v := range row.Values[r.valIdx] {
+				dest[i] = columnValue(v)
+			}
+			r.valIdx++
+			return nil
+		}
+		r.rowIdx++
+		r.valIdx = 0
+	}
+	if err := r.nextSeries(); err != nil {
+		return err
+	}
+	return r.Next(dest)
+}
+
+// columnValue converts a value decoded with json.Number support into a
+// driver.Value, turning whole numbers into int64 and the rest into float64.
+func columnValue(v interface{}) driver.Value {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// emptyRows is returned where a driver.Rows is required but the statement
+// produces no result set, e.g. insertStmt.Query.
+func emptyRows() *chunkedRows {
+	results := make(chan *Result)
+	close(results)
+	return &chunkedRows{results: results}
+}
@@ -0,0 +1,300 @@
+package influxdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultMaxSegmentSize = 8 << 20 // 8MB per segment before rotating
+
+// spoolEntry is the unit persisted to a segment file: the line-protocol
+// batch together with the WriteConfig it must be replayed with.
+type spoolEntry struct {
+	Data []byte
+	Cfg  WriteConfig
+}
+
+// spool is an append-only, segmented, on-disk queue of spoolEntry values.
+// Entries are framed with a 4-byte big-endian length prefix. A checkpoint
+// file records the (segment, offset) of the next entry to replay, so a
+// spool opened again after a restart resumes exactly where it left off.
+type spool struct {
+	dir     string
+	maxSize int64
+
+	mu sync.Mutex
+
+	writeSeg   *os.File
+	writeIndex int
+	writeSize  int64
+
+	readIndex  int
+	readOffset int64
+	lastFrame  int64
+}
+
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &spool{dir: dir, maxSize: maxSize}
+
+	segs, err := s.segmentIndexes()
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		if err := s.rotateLocked(); err != nil {
+			return nil, err
+		}
+	} else {
+		s.writeIndex = segs[len(segs)-1]
+		f, err := os.OpenFile(s.segmentPath(s.writeIndex), os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		s.writeSeg = f
+		if fi, err := f.Stat(); err == nil {
+			s.writeSize = fi.Size()
+		}
+		s.readIndex = segs[0]
+	}
+
+	if err := s.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spool) segmentPath(i int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("segment-%06d.log", i))
+}
+
+func (s *spool) checkpointPath() string {
+	return filepath.Join(s.dir, "checkpoint")
+}
+
+func (s *spool) segmentIndexes() ([]int, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "segment-") || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "segment-"), ".log"))
+		if err != nil {
+			continue
+		}
+		indexes = append(indexes, n)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
+func (s *spool) loadCheckpoint() error {
+	b, err := ioutil.ReadFile(s.checkpointPath())
+	if os.IsNotExist(err) {
+		if s.readIndex == 0 {
+			s.readIndex = s.writeIndex
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 {
+		return nil
+	}
+	index, err1 := strconv.Atoi(fields[0])
+	offset, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	s.readIndex = index
+	s.readOffset = offset
+	return nil
+}
+
+// saveCheckpointLocked persists the current read position. It must be
+// called with s.mu held.
+func (s *spool) saveCheckpointLocked() error {
+	tmp := s.checkpointPath() + ".tmp"
+	content := fmt.Sprintf("%d %d\n", s.readIndex, s.readOffset)
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.checkpointPath())
+}
+
+// rotateLocked closes the current write segment, if any, and opens the
+// next one. It must be called with s.mu held.
+func (s *spool) rotateLocked() error {
+	if s.writeSeg != nil {
+		if err := s.writeSeg.Close(); err != nil {
+			return err
+		}
+	}
+	s.writeIndex++
+	f, err := os.OpenFile(s.segmentPath(s.writeIndex), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	s.writeSeg = f
+	s.writeSize = 0
+	if s.readIndex == 0 {
+		s.readIndex = s.writeIndex
+	}
+	return nil
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func frameSize(payload []byte) int64 { return 4 + int64(len(payload)) }
+
+// append persists data/cfg to the spool's active segment, rotating to a
+// new segment first if the active one has grown past
+// defaultMaxSegmentSize.
+func (s *spool) append(data []byte, cfg *WriteConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxSize > 0 && s.sizeLocked() >= s.maxSize {
+		return fmt.Errorf("hinted handoff spool at %s is full", s.dir)
+	}
+
+	payload, err := json.Marshal(spoolEntry{Data: data, Cfg: *cfg})
+	if err != nil {
+		return err
+	}
+	if s.writeSize >= defaultMaxSegmentSize {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	if err := writeFrame(s.writeSeg, payload); err != nil {
+		return err
+	}
+	s.writeSize += frameSize(payload)
+	return s.writeSeg.Sync()
+}
+
+func (s *spool) sizeLocked() int64 {
+	indexes, err := s.segmentIndexes()
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, i := range indexes {
+		if fi, err := os.Stat(s.segmentPath(i)); err == nil {
+			total += fi.Size()
+		}
+	}
+	return total
+}
+
+// peek returns the next queued entry without removing it; call advance
+// after successfully replaying it. It returns a nil entry once the spool
+// has been fully drained.
+func (s *spool) peek() (*spoolEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		path := s.segmentPath(s.readIndex)
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, seekErr := f.Seek(s.readOffset, io.SeekStart)
+		if seekErr != nil {
+			f.Close()
+			return nil, seekErr
+		}
+		payload, readErr := readFrame(f)
+		f.Close()
+
+		if readErr != nil {
+			if s.readIndex < s.writeIndex {
+				// This segment is fully drained and will never receive
+				// more writes: remove it and move on to the next one.
+				os.Remove(path)
+				s.readIndex++
+				s.readOffset = 0
+				if err := s.saveCheckpointLocked(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, nil
+		}
+
+		var entry spoolEntry
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			// A corrupt frame should not wedge the spool forever: skip it.
+			s.lastFrame = frameSize(payload)
+			s.advanceLocked()
+			continue
+		}
+		s.lastFrame = frameSize(payload)
+		return &entry, nil
+	}
+}
+
+// advance marks the entry last returned by peek as consumed.
+func (s *spool) advance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advanceLocked()
+}
+
+func (s *spool) advanceLocked() {
+	s.readOffset += s.lastFrame
+	s.lastFrame = 0
+	s.saveCheckpointLocked()
+}
+
+func (s *spool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeSeg == nil {
+		return nil
+	}
+	return s.writeSeg.Close()
+}
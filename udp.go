@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net"
 	"time"
@@ -13,6 +14,12 @@ const (
 	UDPPayloadSize = 512
 )
 
+// udpErrorBufferSize is the capacity of the channel returned by
+// udpclient.Errors. UDP writes are fire-and-forget, so a bounded buffer
+// means a caller that never drains the channel can't block sends; once it
+// fills up, newer errors are simply dropped and the older unread ones kept.
+const udpErrorBufferSize = 16
+
 // UDPConfig is the config data needed to create a UDP Client
 type UDPConfig struct {
 	// Addr should be of the form "host:port"
@@ -22,6 +29,10 @@ type UDPConfig struct {
 	// PayloadSize is the maximum size of a UDP client message, optional
 	// Tune this based on your network. Defaults to UDPBufferSize.
 	PayloadSize int
+
+	// WriteBufferSize sets the socket's send buffer size via
+	// SetWriteBuffer, optional. Zero leaves the OS default in place.
+	WriteBufferSize int
 }
 
 // NewUDPClient returns a client interface for writing to an InfluxDB UDP
@@ -38,6 +49,13 @@ func NewUDPClient(conf UDPConfig) (Client, error) {
 		return nil, err
 	}
 
+	if conf.WriteBufferSize > 0 {
+		if err := conn.SetWriteBuffer(conf.WriteBufferSize); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
 	payloadSize := conf.PayloadSize
 	if payloadSize == 0 {
 		payloadSize = UDPPayloadSize
@@ -46,57 +64,109 @@ func NewUDPClient(conf UDPConfig) (Client, error) {
 	return &udpclient{
 		conn:        conn,
 		payloadSize: payloadSize,
+		errors:      make(chan error, udpErrorBufferSize),
 	}, nil
 }
 
 type udpclient struct {
 	conn        *net.UDPConn
 	payloadSize int
+	errors      chan error
 }
 
 func (uc *udpclient) Query(q Query) (*Response, error) {
 	return nil, fmt.Errorf("Querying via UDP is not supported")
 }
 
+func (uc *udpclient) QueryContext(ctx context.Context, q Query) (*Response, error) {
+	return nil, fmt.Errorf("Querying via UDP is not supported")
+}
+
+func (uc *udpclient) QueryChunked(q Query) (<-chan *Result, error) {
+	return nil, fmt.Errorf("Querying via UDP is not supported")
+}
+
+func (uc *udpclient) QueryChunkedContext(ctx context.Context, q Query) (<-chan *Result, error) {
+	return nil, fmt.Errorf("Querying via UDP is not supported")
+}
+
 // Ping will check to see if the server is up with an optional timeout on waiting for leader.
 // Ping returns how long the request took, the version of the server it connected to, and an error if one occurred.
 func (uc *udpclient) Ping(timeout time.Duration) (time.Duration, string, error) {
 	return 0, "", nil
 }
 
+// PingContext is like Ping, ignoring ctx: a UDP socket has no request to
+// cancel.
+func (uc *udpclient) PingContext(ctx context.Context, timeout time.Duration) (time.Duration, string, error) {
+	return uc.Ping(timeout)
+}
+
 // Close releases the udpclient's resources.
 func (uc *udpclient) Close() error {
 	return uc.conn.Close()
 }
+
+// Errors returns a channel of errors encountered sending individual UDP
+// datagrams. UDP writes are fire-and-forget: Write only reports errors
+// that prevent it from framing the data at all (e.g. a line longer than
+// the configured payload size), so callers that want to observe
+// per-datagram send failures should drain this channel.
+func (uc *udpclient) Errors() <-chan error {
+	return uc.errors
+}
+
 func (uc *udpclient) Write(lineData []byte, cfg *WriteConfig) error {
-	return udpWrite(uc.conn, lineData, uc.payloadSize)
+	return udpWrite(uc.conn, uc.errors, lineData, uc.payloadSize)
 }
 
-func udpWrite(conn net.Conn, data []byte, size int) error {
-	head, tail := []byte(nil), data
-	for {
-		head, tail = udpPayload(tail, size)
-		if len(head) == 0 {
-			break
-		}
-		_, err := conn.Write(data)
-		if err != nil {
-			return err
+// WriteContext is like Write, ignoring ctx: a UDP send is a single
+// non-blocking syscall, not a request that can be cancelled mid-flight.
+func (uc *udpclient) WriteContext(ctx context.Context, lineData []byte, cfg *WriteConfig) error {
+	return uc.Write(lineData, cfg)
+}
+
+// udpWrite packs lineData into one or more UDP datagrams of at most size
+// bytes and sends each in turn. Send errors don't abort the loop - UDP
+// writes are fire-and-forget - they're instead pushed onto errs so a
+// caller can observe them asynchronously.
+func udpWrite(conn net.Conn, errs chan<- error, data []byte, size int) error {
+	payloads, err := udpPayloads(data, size)
+	if err != nil {
+		return err
+	}
+	for _, payload := range payloads {
+		if _, err := conn.Write(payload); err != nil {
+			select {
+			case errs <- err:
+			default:
+			}
 		}
 	}
 	return nil
 }
-func udpPayload(data []byte, size int) (head, tail []byte) {
-	head, tail = nil, data
-	for len(head) < size {
-		if pos := bytes.IndexByte(tail, '\n'); pos > 0 {
-			head, tail = append(head, tail[:pos]...), tail[pos:]
-			continue
+
+// udpPayloads splits data on '\n' boundaries and packs as many complete
+// lines as fit under size into each datagram, never splitting a single
+// line across two datagrams. It returns an error if any single line
+// exceeds size.
+func udpPayloads(data []byte, size int) ([][]byte, error) {
+	var payloads [][]byte
+	for len(data) > 0 {
+		var line []byte
+		if pos := bytes.IndexByte(data, '\n'); pos >= 0 {
+			line, data = data[:pos+1], data[pos+1:]
+		} else {
+			line, data = data, nil
+		}
+		if len(line) > size {
+			return nil, fmt.Errorf("influxdb: line of %d bytes exceeds UDP payload size of %d bytes", len(line), size)
+		}
+		if n := len(payloads); n > 0 && len(payloads[n-1])+len(line) <= size {
+			payloads[n-1] = append(payloads[n-1], line...)
+		} else {
+			payloads = append(payloads, append([]byte(nil), line...))
 		}
-		break
-	}
-	if head == nil {
-		head, tail = data, nil
 	}
-	return head, tail
+	return payloads, nil
 }